@@ -0,0 +1,84 @@
+// Package dispatcher defines the consume request dispatch tree shared by
+// every consumer tier (group, topic, partition, ...): the `Tier`/`ChildSpec`
+// spawn contract, and the `Request`/`Response` pair tiers exchange with
+// their callers.
+package dispatcher
+
+import (
+	"context"
+	"time"
+
+	"github.com/mailgun/kafka-pixy/consumer"
+)
+
+// Key identifies a child tier within its parent, e.g. a topic name within a
+// group, or a partition number within a topic.
+type Key string
+
+// Tier is implemented by every level of the consume request dispatch tree.
+type Tier interface {
+	Key() Key
+}
+
+// ChildSpec is handed to a tier when it is spawned. It identifies the tier
+// within its parent and gives it access to the requests it must serve.
+type ChildSpec interface {
+	// Key identifies this child within its parent, e.g. the topic name for
+	// a `topiccsm.T`.
+	Key() Key
+	// Requests returns the channel the tier must read consume requests
+	// from. It is closed to signal the tier to shut down.
+	Requests() <-chan Request
+	// OffsetPolicy returns the offset-reset policy requested for this
+	// child's subscription, e.g. `consumer.OffsetNewest`,
+	// `consumer.OffsetOldest`, an explicit numeric offset, or a timestamp
+	// to resolve via Kafka's OffsetsForTimes API.
+	OffsetPolicy() consumer.OffsetPolicy
+	// Dispose releases the resources associated with this child spec. It
+	// is called exactly once, when the tier stops running.
+	Dispose()
+}
+
+// Request is a single consume request traveling down the dispatch tree.
+type Request struct {
+	Timestamp  time.Time
+	ResponseCh chan<- Response
+	// Context is derived from the upstream HTTP/gRPC call and is canceled
+	// as soon as the caller goes away (client disconnect, deadline
+	// exceeded). Tiers must check it before dequeuing a message from
+	// `Messages()` and on every subsequent wait, so a canceled request
+	// never claims a message it cannot deliver. Front-ends must always
+	// set it; it is never nil.
+	Context context.Context
+
+	// Stream, if true, asks the topic consumer to forward every message it
+	// receives onto StreamCh for as long as the client stays connected,
+	// instead of replying once on ResponseCh. Set by the long-lived
+	// WebSocket/SSE `/topics/{topic}/messages` front-end.
+	Stream bool
+	// StreamCh receives every message offered to this request while
+	// Stream is true.
+	StreamCh chan<- consumer.Message
+	// StopCh is closed by the caller when the client disconnects, so the
+	// topic consumer can stop forwarding messages to StreamCh.
+	StopCh <-chan struct{}
+
+	// MaxMessages, if greater than zero, asks the topic consumer to
+	// collect up to this many messages into a single batched response
+	// (Response.Msgs) instead of replying with one message on Msg. A
+	// value of 1 still takes the batch path, so a caller can explicitly
+	// ask for a one-message batch response.
+	MaxMessages int
+	// MaxWait bounds how long the topic consumer waits for MaxMessages to
+	// fill once it has collected at least one message. It is ignored when
+	// MaxMessages is 0. The request's overall LongPollingTimeout budget
+	// still applies on top of it.
+	MaxWait time.Duration
+}
+
+// Response is returned on a Request's ResponseCh.
+type Response struct {
+	Msg  consumer.Message
+	Msgs []consumer.Message
+	Err  error
+}
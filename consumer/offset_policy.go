@@ -0,0 +1,54 @@
+package consumer
+
+import "time"
+
+// OffsetPolicyKind selects how an OffsetPolicy's Value/Timestamp field is
+// interpreted when a topic consumer is spawned with no committed offset to
+// resume from, or when the caller explicitly wants to seek.
+type OffsetPolicyKind int
+
+const (
+	// OffsetPolicyDefault resumes from the group's committed offset, or
+	// OffsetNewest if there is none.
+	OffsetPolicyDefault OffsetPolicyKind = iota
+	// OffsetPolicyNewest seeks to the newest available offset.
+	OffsetPolicyNewest
+	// OffsetPolicyOldest seeks to the oldest available offset.
+	OffsetPolicyOldest
+	// OffsetPolicyExplicit seeks to the numeric offset in Value.
+	OffsetPolicyExplicit
+	// OffsetPolicyTimestamp seeks to the offset of the first message at or
+	// after Timestamp, resolved via Kafka's OffsetsForTimes API.
+	OffsetPolicyTimestamp
+)
+
+// Sarama-compatible sentinel offsets, for callers building an
+// OffsetPolicyExplicit value directly instead of going through
+// OffsetPolicyNewest/OffsetPolicyOldest.
+const (
+	OffsetNewest int64 = -1
+	OffsetOldest int64 = -2
+)
+
+// OffsetPolicy describes where a topic consumer should start reading from
+// when a consumer group has no committed offset for a topic, or when the
+// caller explicitly wants to seek. It is recorded on the topic consumer at
+// spawn time and forwarded down to the partition consumers/multiplexer,
+// which apply it when they have no committed offset to resume from.
+type OffsetPolicy struct {
+	Kind OffsetPolicyKind
+	// Value is the explicit numeric offset to seek to. Only meaningful
+	// when Kind is OffsetPolicyExplicit.
+	Value int64
+	// Timestamp is the point in time to resolve to a numeric offset via
+	// OffsetsForTimes. Only meaningful when Kind is OffsetPolicyTimestamp.
+	Timestamp time.Time
+}
+
+// OffsetResolver translates a timestamp-based OffsetPolicy into a concrete
+// Kafka offset, mirroring the semantics of Kafka's OffsetsForTimes API. The
+// multiplexer uses it to resolve OffsetPolicyTimestamp before seeding a
+// partition consumer.
+type OffsetResolver interface {
+	OffsetsForTimes(topic string, partition int32, timestamp time.Time) (int64, error)
+}
@@ -0,0 +1,97 @@
+package topiccsm
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/mailgun/kafka-pixy/consumer"
+	"github.com/mailgun/kafka-pixy/consumer/dispatcher"
+)
+
+func TestBatch_FillsUpToMaxMessages(t *testing.T) {
+	tc := &T{messagesCh: make(chan consumer.Message)}
+	responseCh := make(chan dispatcher.Response, 1)
+	req := dispatcher.Request{Context: context.Background(), ResponseCh: responseCh, MaxMessages: 2, MaxWait: time.Second}
+
+	go func() {
+		tc.messagesCh <- newMessage(1)
+		tc.messagesCh <- newMessage(2)
+	}()
+	tc.batch(req, time.Second)
+
+	rs := <-responseCh
+	if len(rs.Msgs) != 2 {
+		t.Fatalf("got %d messages, want 2", len(rs.Msgs))
+	}
+}
+
+func TestBatch_MaxMessagesOfOneTakesBatchPath(t *testing.T) {
+	tc := &T{messagesCh: make(chan consumer.Message)}
+	responseCh := make(chan dispatcher.Response, 1)
+	req := dispatcher.Request{Context: context.Background(), ResponseCh: responseCh, MaxMessages: 1, MaxWait: time.Second}
+
+	go func() {
+		tc.messagesCh <- newMessage(1)
+	}()
+	tc.batch(req, time.Second)
+
+	rs := <-responseCh
+	if len(rs.Msgs) != 1 {
+		t.Fatalf("got %d messages, want 1 via Msgs", len(rs.Msgs))
+	}
+}
+
+func TestBatch_ReturnsPartialBatchOnMaxWait(t *testing.T) {
+	tc := &T{messagesCh: make(chan consumer.Message)}
+	responseCh := make(chan dispatcher.Response, 1)
+	req := dispatcher.Request{Context: context.Background(), ResponseCh: responseCh, MaxMessages: 5, MaxWait: 10 * time.Millisecond}
+
+	go func() {
+		tc.messagesCh <- newMessage(1)
+	}()
+	tc.batch(req, time.Second)
+
+	rs := <-responseCh
+	if len(rs.Msgs) != 1 {
+		t.Fatalf("got %d messages, want 1", len(rs.Msgs))
+	}
+}
+
+func TestBatch_MaxWaitStartsAfterFirstMessageNotAtEntry(t *testing.T) {
+	tc := &T{messagesCh: make(chan consumer.Message)}
+	responseCh := make(chan dispatcher.Response, 1)
+	// MaxWait is short enough that, if it started counting at the call to
+	// batch rather than at the first message, it would expire long before
+	// the first message is even sent.
+	req := dispatcher.Request{Context: context.Background(), ResponseCh: responseCh, MaxMessages: 2, MaxWait: 20 * time.Millisecond}
+
+	go func() {
+		time.Sleep(100 * time.Millisecond)
+		tc.messagesCh <- newMessage(1)
+	}()
+	tc.batch(req, time.Second)
+
+	rs := <-responseCh
+	if len(rs.Msgs) != 1 {
+		t.Fatalf("got %d messages, want 1 collected after the delayed send", len(rs.Msgs))
+	}
+}
+
+func TestBatch_MaxWaitZeroIsUnbounded(t *testing.T) {
+	tc := &T{messagesCh: make(chan consumer.Message)}
+	responseCh := make(chan dispatcher.Response, 1)
+	req := dispatcher.Request{Context: context.Background(), ResponseCh: responseCh, MaxMessages: 2, MaxWait: 0}
+
+	go func() {
+		tc.messagesCh <- newMessage(1)
+		time.Sleep(50 * time.Millisecond)
+		tc.messagesCh <- newMessage(2)
+	}()
+	tc.batch(req, time.Second)
+
+	rs := <-responseCh
+	if len(rs.Msgs) != 2 {
+		t.Fatalf("got %d messages, want 2: a zero MaxWait must not cut the batch short", len(rs.Msgs))
+	}
+}
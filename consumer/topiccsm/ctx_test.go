@@ -0,0 +1,61 @@
+package topiccsm
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/mailgun/kafka-pixy/consumer"
+	"github.com/mailgun/kafka-pixy/consumer/dispatcher"
+)
+
+func TestIsCanceled(t *testing.T) {
+	tc := &T{}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	if tc.isCanceled(dispatcher.Request{Context: ctx}) {
+		t.Fatal("fresh context reported as canceled")
+	}
+	cancel()
+	if !tc.isCanceled(dispatcher.Request{Context: ctx}) {
+		t.Fatal("canceled context not reported as canceled")
+	}
+}
+
+func TestStream_StopsOnContextCancellation(t *testing.T) {
+	tc := &T{messagesCh: make(chan consumer.Message), cfg: newTestCfg(time.Second)}
+	ctx, cancel := context.WithCancel(context.Background())
+	req := dispatcher.Request{Context: ctx, StreamCh: make(chan consumer.Message), StopCh: make(chan struct{})}
+
+	done := make(chan struct{})
+	go func() {
+		tc.stream(req)
+		close(done)
+	}()
+
+	cancel()
+	select {
+	case <-done:
+	case <-time.After(time.Second):
+		t.Fatal("stream did not return after context was canceled")
+	}
+}
+
+func TestBatch_StopsOnContextCancellation(t *testing.T) {
+	tc := &T{messagesCh: make(chan consumer.Message)}
+	ctx, cancel := context.WithCancel(context.Background())
+	responseCh := make(chan dispatcher.Response, 1)
+	req := dispatcher.Request{Context: ctx, ResponseCh: responseCh, MaxMessages: 5, MaxWait: time.Second}
+
+	cancel()
+	tc.batch(req, time.Second)
+
+	select {
+	case rs := <-responseCh:
+		if rs.Err != consumer.ErrRequestTimeout {
+			t.Fatalf("got err %v, want ErrRequestTimeout for an empty canceled batch", rs.Err)
+		}
+	default:
+		t.Fatal("batch did not reply on ResponseCh")
+	}
+}
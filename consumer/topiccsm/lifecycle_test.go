@@ -0,0 +1,54 @@
+package topiccsm
+
+import (
+	"testing"
+	"time"
+)
+
+func TestSubscribeLifecycleEvents_ReplaysRegistrationOnLateSubscribe(t *testing.T) {
+	tc := &T{}
+
+	ch := make(chan LifecycleEvent, 1)
+	tc.SubscribeLifecycleEvents(ch)
+
+	select {
+	case ev := <-ch:
+		if ev != EvRegistered {
+			t.Fatalf("got %v, want EvRegistered replayed on subscribe", ev)
+		}
+	case <-time.After(time.Second):
+		t.Fatal("EvRegistered was not replayed to a late subscriber")
+	}
+}
+
+func TestPublishLifecycleEvent_NonBlockingWithoutSubscriber(t *testing.T) {
+	tc := &T{}
+	done := make(chan struct{})
+	go func() {
+		tc.publishLifecycleEvent(EvExpired)
+		close(done)
+	}()
+	select {
+	case <-done:
+	case <-time.After(time.Second):
+		t.Fatal("publishLifecycleEvent blocked with no subscriber")
+	}
+}
+
+func TestReportBrokerConnLoss_PublishesToSubscriber(t *testing.T) {
+	tc := &T{}
+	ch := make(chan LifecycleEvent, 2)
+	tc.SubscribeLifecycleEvents(ch)
+	<-ch // drain the replayed EvRegistered
+
+	tc.ReportBrokerConnLoss()
+
+	select {
+	case ev := <-ch:
+		if ev != EvBrokerConnLoss {
+			t.Fatalf("got %v, want EvBrokerConnLoss", ev)
+		}
+	case <-time.After(time.Second):
+		t.Fatal("EvBrokerConnLoss was not published")
+	}
+}
@@ -0,0 +1,65 @@
+package topiccsm
+
+import (
+	"testing"
+	"time"
+
+	"github.com/mailgun/kafka-pixy/consumer"
+)
+
+func TestOffsetPolicy_ReturnsRecordedPolicy(t *testing.T) {
+	wantPolicy := consumer.OffsetPolicy{Kind: consumer.OffsetPolicyOldest}
+	tc := &T{offsetPolicy: wantPolicy}
+
+	if got := tc.OffsetPolicy(); got != wantPolicy {
+		t.Fatalf("got %+v, want %+v", got, wantPolicy)
+	}
+}
+
+func TestResolveOffset_NewestAndOldest(t *testing.T) {
+	newest := &T{offsetPolicy: consumer.OffsetPolicy{Kind: consumer.OffsetPolicyNewest}}
+	if got, err := newest.ResolveOffset(nil, 0); err != nil || got != consumer.OffsetNewest {
+		t.Fatalf("got (%d, %v), want (%d, nil)", got, err, consumer.OffsetNewest)
+	}
+
+	oldest := &T{offsetPolicy: consumer.OffsetPolicy{Kind: consumer.OffsetPolicyOldest}}
+	if got, err := oldest.ResolveOffset(nil, 0); err != nil || got != consumer.OffsetOldest {
+		t.Fatalf("got (%d, %v), want (%d, nil)", got, err, consumer.OffsetOldest)
+	}
+}
+
+func TestResolveOffset_Explicit(t *testing.T) {
+	tc := &T{offsetPolicy: consumer.OffsetPolicy{Kind: consumer.OffsetPolicyExplicit, Value: 123}}
+	if got, err := tc.ResolveOffset(nil, 0); err != nil || got != 123 {
+		t.Fatalf("got (%d, %v), want (123, nil)", got, err)
+	}
+}
+
+type fakeOffsetResolver struct {
+	offset int64
+	err    error
+}
+
+func (r *fakeOffsetResolver) OffsetsForTimes(topic string, partition int32, ts time.Time) (int64, error) {
+	return r.offset, r.err
+}
+
+func TestResolveOffset_TimestampDelegatesToResolver(t *testing.T) {
+	ts := time.Unix(1700000000, 0)
+	tc := &T{topic: "my-topic", offsetPolicy: consumer.OffsetPolicy{Kind: consumer.OffsetPolicyTimestamp, Timestamp: ts}}
+	resolver := &fakeOffsetResolver{offset: 555}
+
+	got, err := tc.ResolveOffset(resolver, 3)
+	if err != nil || got != 555 {
+		t.Fatalf("got (%d, %v), want (555, nil)", got, err)
+	}
+}
+
+func TestResolveOffset_TimestampWithNilResolverReturnsError(t *testing.T) {
+	ts := time.Unix(1700000000, 0)
+	tc := &T{topic: "my-topic", offsetPolicy: consumer.OffsetPolicy{Kind: consumer.OffsetPolicyTimestamp, Timestamp: ts}}
+
+	if _, err := tc.ResolveOffset(nil, 3); err == nil {
+		t.Fatal("got nil error, want an error for a nil resolver instead of a panic")
+	}
+}
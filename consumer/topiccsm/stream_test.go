@@ -0,0 +1,89 @@
+package topiccsm
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/mailgun/kafka-pixy/config"
+	"github.com/mailgun/kafka-pixy/consumer"
+	"github.com/mailgun/kafka-pixy/consumer/dispatcher"
+)
+
+func newMessage(offset int64) consumer.Message {
+	return consumer.Message{Offset: offset, EventsCh: make(chan consumer.Event, 1)}
+}
+
+func newTestCfg(subscriptionTimeout time.Duration) *config.Proxy {
+	cfg := &config.Proxy{}
+	cfg.Consumer.SubscriptionTimeout = subscriptionTimeout
+	return cfg
+}
+
+func TestStream_DeliversMessagesUntilStopped(t *testing.T) {
+	tc := &T{messagesCh: make(chan consumer.Message), cfg: newTestCfg(time.Second)}
+	streamCh := make(chan consumer.Message, 1)
+	stopCh := make(chan struct{})
+	req := dispatcher.Request{Context: context.Background(), StreamCh: streamCh, StopCh: stopCh}
+
+	done := make(chan struct{})
+	go func() {
+		tc.stream(req)
+		close(done)
+	}()
+
+	msg := newMessage(42)
+	tc.messagesCh <- msg
+
+	select {
+	case got := <-streamCh:
+		if got.Offset != msg.Offset {
+			t.Fatalf("got offset %d, want %d", got.Offset, msg.Offset)
+		}
+	case <-time.After(time.Second):
+		t.Fatal("message was not forwarded to StreamCh")
+	}
+
+	select {
+	case <-msg.EventsCh:
+	case <-time.After(time.Second):
+		t.Fatal("EvOffered was not emitted for a delivered message")
+	}
+
+	close(stopCh)
+	select {
+	case <-done:
+	case <-time.After(time.Second):
+		t.Fatal("stream did not return after StopCh was closed")
+	}
+}
+
+func TestStream_DoesNotOfferMessageLostOnDisconnect(t *testing.T) {
+	tc := &T{messagesCh: make(chan consumer.Message), cfg: newTestCfg(time.Second)}
+	// StreamCh has no reader, so the handoff below can never succeed.
+	streamCh := make(chan consumer.Message)
+	stopCh := make(chan struct{})
+	req := dispatcher.Request{Context: context.Background(), StreamCh: streamCh, StopCh: stopCh}
+
+	done := make(chan struct{})
+	go func() {
+		tc.stream(req)
+		close(done)
+	}()
+
+	msg := newMessage(7)
+	tc.messagesCh <- msg
+	close(stopCh)
+
+	select {
+	case <-done:
+	case <-time.After(time.Second):
+		t.Fatal("stream did not return after StopCh was closed")
+	}
+
+	select {
+	case ev := <-msg.EventsCh:
+		t.Fatalf("EvOffered was emitted for a message that was never delivered: %v", ev)
+	default:
+	}
+}
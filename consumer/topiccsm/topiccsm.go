@@ -1,6 +1,7 @@
 package topiccsm
 
 import (
+	"errors"
 	"fmt"
 	"sync"
 	"time"
@@ -13,11 +14,50 @@ import (
 
 var requestTimeoutRs = dispatcher.Response{Err: consumer.ErrRequestTimeout}
 
+// errOffsetResolverRequired is returned by ResolveOffset when the offset
+// policy is `consumer.OffsetPolicyTimestamp` but no resolver was supplied.
+var errOffsetResolverRequired = errors.New("topiccsm: OffsetPolicyTimestamp requires a non-nil OffsetResolver")
+
+// LifecycleEvent describes a transition in a topic consumer's subscription
+// liveness. HTTP/gRPC front-ends can opt into receiving these, e.g. to
+// drive an SSE endpoint, via `SubscribeLifecycleEvents`.
+type LifecycleEvent int
+
+const (
+	// EvRegistered is published once, right after the topic consumer has
+	// been admitted to the dispatch tree. It is replayed to any subscriber
+	// that attaches after the fact, since registration has necessarily
+	// already happened by the time a caller can reach a `*T` to subscribe.
+	EvRegistered LifecycleEvent = iota
+	// EvRevoked is published when the topic consumer is torn down because
+	// the dispatcher signaled a rebalance-driven revocation.
+	EvRevoked
+	// EvExpired is published when the subscription has not been renewed
+	// for `Config.Consumer.SubscriptionTimeout` and is about to be
+	// deregistered.
+	EvExpired
+	// EvBrokerConnLoss is published when the multiplexer reports that the
+	// underlying consumer group session has lost its connection to the
+	// broker, via `ReportBrokerConnLoss`.
+	EvBrokerConnLoss
+)
+
 // T implements a consumer request dispatch tier responsible for a particular
 // topic. It receives requests on the `Requests()` channel and replies with
 // messages received on `Messages()` channel. If there has been no message
 // received for `Config.Consumer.LongPollingTimeout` then a timeout error is
-// sent to the requests' reply channel.
+// sent to the requests' reply channel. A request may also opt into streaming
+// mode, in which case messages are forwarded onto the request's `StreamCh`
+// for as long as the client stays connected, instead of terminating after
+// the first message; or it may request a batch of up to `MaxMessages`
+// messages, returned once the batch is full, `MaxWait` elapses, or the
+// long polling timeout is reached. Every request carries a `Context` that is
+// checked before a message is dequeued from `Messages()` and on every wait,
+// so that a canceled request never claims a message it cannot deliver.
+// Callers may also opt into subscription liveness notifications via
+// `SubscribeLifecycleEvents`; registration, revocation, expiry and broker
+// connectivity loss are then published to the subscribed channel as
+// `LifecycleEvent`s.
 //
 // implements `dispatcher.Tier`.
 // implements `multiplexer.Out`.
@@ -27,9 +67,12 @@ type T struct {
 	cfg                *config.Proxy
 	group              string
 	topic              string
+	offsetPolicy       consumer.OffsetPolicy
 	expireTimer        *time.Timer
 	nilOrExpireTimerCh <-chan time.Time
 	lifespanCh         chan<- *T
+	lifecycleMu        sync.Mutex
+	lifecycleCh        chan<- LifecycleEvent
 	messagesCh         chan consumer.Message
 	wg                 sync.WaitGroup
 }
@@ -42,12 +85,13 @@ func Spawn(parentActDesc *actor.Descriptor, group string, childSpec dispatcher.C
 	actDesc.AddLogField("kafka.group", group)
 	actDesc.AddLogField("kafka.topic", topic)
 	tc := T{
-		actDesc:    actDesc,
-		childSpec:  childSpec,
-		cfg:        cfg,
-		group:      group,
-		topic:      topic,
-		lifespanCh: lifespanCh,
+		actDesc:      actDesc,
+		childSpec:    childSpec,
+		cfg:          cfg,
+		group:        group,
+		topic:        topic,
+		offsetPolicy: childSpec.OffsetPolicy(),
+		lifespanCh:   lifespanCh,
 
 		// Messages channel must be non-buffered. Otherwise we might end up
 		// buffering a message from a partition that no longer belongs to this
@@ -63,6 +107,40 @@ func (tc *T) Topic() string {
 	return tc.topic
 }
 
+// OffsetPolicy returns the offset-reset policy this topic consumer was
+// spawned with, e.g. `consumer.OffsetNewest`, `consumer.OffsetOldest`, a
+// numeric offset, or an offset-by-timestamp request. The multiplexer
+// consults it to seed the partition consumers it creates for this topic
+// when the group has no committed offset to resume from.
+func (tc *T) OffsetPolicy() consumer.OffsetPolicy {
+	return tc.offsetPolicy
+}
+
+// ResolveOffset translates `tc.OffsetPolicy()` into a concrete Kafka offset
+// for the given partition. It is intended to be called by the multiplexer
+// when it is about to spawn a partition consumer with no committed offset to
+// resume from, or when the caller explicitly wants to seek; wiring that call
+// is out of scope here, since the multiplexer lives outside this package.
+// `resolver` is only consulted when the policy is
+// `consumer.OffsetPolicyTimestamp`, in which case it must be non-nil.
+func (tc *T) ResolveOffset(resolver consumer.OffsetResolver, partition int32) (int64, error) {
+	switch tc.offsetPolicy.Kind {
+	case consumer.OffsetPolicyNewest:
+		return consumer.OffsetNewest, nil
+	case consumer.OffsetPolicyOldest:
+		return consumer.OffsetOldest, nil
+	case consumer.OffsetPolicyExplicit:
+		return tc.offsetPolicy.Value, nil
+	case consumer.OffsetPolicyTimestamp:
+		if resolver == nil {
+			return 0, errOffsetResolverRequired
+		}
+		return resolver.OffsetsForTimes(tc.topic, partition, tc.offsetPolicy.Timestamp)
+	default:
+		return consumer.OffsetNewest, nil
+	}
+}
+
 // implements `multiplexer.Out`
 func (tc *T) Messages() chan<- consumer.Message {
 	return tc.messagesCh
@@ -71,6 +149,7 @@ func (tc *T) Messages() chan<- consumer.Message {
 func (tc *T) run() {
 	defer tc.childSpec.Dispose()
 	tc.lifespanCh <- tc
+	tc.publishLifecycleEvent(EvRegistered)
 	defer func() {
 		tc.lifespanCh <- tc
 	}()
@@ -80,12 +159,24 @@ func (tc *T) run() {
 		case consumeReq, ok := <-tc.childSpec.Requests():
 			if !ok {
 				tc.actDesc.Log().Info("Signaled to shutdown")
+				tc.publishLifecycleEvent(EvRevoked)
 				return
 			}
 			tc.stopExpireTimer()
 
 			requestAge := time.Now().UTC().Sub(consumeReq.Timestamp)
 			ttl := tc.cfg.Consumer.LongPollingTimeout - requestAge
+
+			// Streaming requests carry no `ResponseCh` — they are served
+			// entirely through `StreamCh`/`StopCh` — so they must be
+			// dispatched before anything below that replies on
+			// `ResponseCh`. Otherwise a canceled or already-expired stream
+			// request would block forever trying to send on a channel it
+			// doesn't have.
+			if consumeReq.Stream {
+				tc.stream(consumeReq)
+				continue
+			}
 			// The request has been waiting in the buffer for too long. If we
 			// reply with a fetched message, then there is a good chance that the
 			// client won't receive it due to the client HTTP timeout. Therefore
@@ -94,15 +185,30 @@ func (tc *T) run() {
 				consumeReq.ResponseCh <- requestTimeoutRs
 				continue
 			}
+			// The caller may have already gone away while the request was
+			// sitting in the buffer. Check that before touching
+			// `tc.messagesCh` at all, so that a message never gets dequeued
+			// only to have nowhere to go.
+			if tc.isCanceled(consumeReq) {
+				consumeReq.ResponseCh <- dispatcher.Response{Err: consumeReq.Context.Err()}
+				continue
+			}
+			if consumeReq.MaxMessages >= 1 {
+				tc.batch(consumeReq, ttl)
+				continue
+			}
 			select {
 			case msg := <-tc.messagesCh:
 				msg.EventsCh <- consumer.Event{consumer.EvOffered, msg.Offset}
 				consumeReq.ResponseCh <- dispatcher.Response{Msg: msg}
+			case <-consumeReq.Context.Done():
+				consumeReq.ResponseCh <- dispatcher.Response{Err: consumeReq.Context.Err()}
 			case <-time.After(ttl):
 				consumeReq.ResponseCh <- requestTimeoutRs
 			}
 		case <-tc.nilOrExpireTimerCh:
 			tc.actDesc.Log().Info("Topic registration expired")
+			tc.publishLifecycleEvent(EvExpired)
 			return
 		default:
 			tc.ensureExpireTimer()
@@ -110,10 +216,147 @@ func (tc *T) run() {
 	}
 }
 
+// stream forwards messages from `tc.messagesCh` directly onto the
+// request's `StreamCh` for as long as the client stays connected, emitting
+// `EvOffered` for every message exactly as the single-shot path does — but
+// only once the message has actually been handed to StreamCh, so a message
+// that arrives just as the client disconnects is never marked offered
+// without having been delivered. Unlike the single-shot and batch paths, a
+// stream is not bounded by the per-request `LongPollingTimeout`: it is meant
+// to serve one long-lived connection, so it runs until the client
+// disconnects (`StopCh`/`Context`) or `Config.Consumer.SubscriptionTimeout`
+// elapses with no activity.
+func (tc *T) stream(consumeReq dispatcher.Request) {
+	nilOrDeadlineCh := time.After(tc.cfg.Consumer.SubscriptionTimeout)
+	for {
+		if tc.isCanceled(consumeReq) {
+			return
+		}
+		select {
+		case msg := <-tc.messagesCh:
+			select {
+			case consumeReq.StreamCh <- msg:
+				msg.EventsCh <- consumer.Event{consumer.EvOffered, msg.Offset}
+				nilOrDeadlineCh = time.After(tc.cfg.Consumer.SubscriptionTimeout)
+			case <-consumeReq.StopCh:
+				return
+			case <-consumeReq.Context.Done():
+				return
+			}
+		case <-consumeReq.StopCh:
+			return
+		case <-consumeReq.Context.Done():
+			return
+		case <-nilOrDeadlineCh:
+			return
+		}
+	}
+}
+
+// batch drains up to `consumeReq.MaxMessages` from `tc.messagesCh`, emitting
+// `EvOffered` for each, stopping as soon as the batch is full, `MaxWait` has
+// elapsed since the first message was collected, the request's remaining
+// long polling budget (`ttl`) is exhausted, or the request's `Context` is
+// canceled, whichever comes first. `MaxWait` only starts counting once the
+// batch has its first message — an empty batch waits out the full `ttl`
+// rather than returning as soon as `MaxWait` expires — and a zero `MaxWait`
+// means no bound beyond `ttl`. Whatever has been collected by then is sent
+// back on the response channel in `Msgs`; an empty batch is answered with
+// `consumer.ErrRequestTimeout` instead. Each `consumer.Message` in the batch already carries its
+// own partition and offset, so the caller can ack/nack the batch message by
+// message.
+func (tc *T) batch(consumeReq dispatcher.Request, ttl time.Duration) {
+	nilOrDeadlineCh := time.After(ttl)
+	var nilOrMaxWaitCh <-chan time.Time
+	msgs := make([]consumer.Message, 0, consumeReq.MaxMessages)
+fillLoop:
+	for len(msgs) < consumeReq.MaxMessages {
+		if tc.isCanceled(consumeReq) {
+			break
+		}
+		select {
+		case msg := <-tc.messagesCh:
+			msg.EventsCh <- consumer.Event{consumer.EvOffered, msg.Offset}
+			msgs = append(msgs, msg)
+			if nilOrMaxWaitCh == nil && consumeReq.MaxWait > 0 {
+				nilOrMaxWaitCh = time.After(consumeReq.MaxWait)
+			}
+		case <-consumeReq.Context.Done():
+			break fillLoop
+		case <-nilOrMaxWaitCh:
+			break fillLoop
+		case <-nilOrDeadlineCh:
+			break fillLoop
+		}
+	}
+	if len(msgs) == 0 {
+		consumeReq.ResponseCh <- requestTimeoutRs
+		return
+	}
+	consumeReq.ResponseCh <- dispatcher.Response{Msgs: msgs}
+}
+
 func (tc *T) String() string {
 	return tc.actDesc.String()
 }
 
+// SubscribeLifecycleEvents registers ch to receive this topic consumer's
+// `LifecycleEvent`s, e.g. so an HTTP/gRPC front-end can drive a
+// `/topics/{topic}/events` SSE or server-stream endpoint from it. Only one
+// subscriber is supported at a time; subscribing again replaces the
+// previous one. Because registration has necessarily already happened by
+// the time a caller can reach a `*T` to subscribe, `EvRegistered` is
+// replayed to ch so a subscriber that attaches late still observes it. The
+// replay is a non-blocking send: it is dropped if ch has no buffer room and
+// no receiver ready.
+func (tc *T) SubscribeLifecycleEvents(ch chan<- LifecycleEvent) {
+	tc.lifecycleMu.Lock()
+	tc.lifecycleCh = ch
+	tc.lifecycleMu.Unlock()
+	if ch != nil {
+		select {
+		case ch <- EvRegistered:
+		default:
+		}
+	}
+}
+
+// ReportBrokerConnLoss publishes `EvBrokerConnLoss` to the subscribed
+// lifecycle channel, if any. It is called by the multiplexer/partition
+// consumers when the underlying consumer group session loses its
+// connection to the broker.
+func (tc *T) ReportBrokerConnLoss() {
+	tc.publishLifecycleEvent(EvBrokerConnLoss)
+}
+
+// publishLifecycleEvent sends ev to the subscribed lifecycle channel, if
+// any. The send is best-effort: a slow or absent subscriber never blocks
+// the topic consumer's own request processing.
+func (tc *T) publishLifecycleEvent(ev LifecycleEvent) {
+	tc.lifecycleMu.Lock()
+	ch := tc.lifecycleCh
+	tc.lifecycleMu.Unlock()
+	if ch == nil {
+		return
+	}
+	select {
+	case ch <- ev:
+	default:
+	}
+}
+
+// isCanceled reports whether the request's context has already been
+// canceled, e.g. because the upstream HTTP/gRPC caller disconnected or its
+// deadline has been exceeded.
+func (tc *T) isCanceled(consumeReq dispatcher.Request) bool {
+	select {
+	case <-consumeReq.Context.Done():
+		return true
+	default:
+		return false
+	}
+}
+
 func (tc *T) stopExpireTimer() {
 	if tc.expireTimer == nil {
 		return